@@ -0,0 +1,48 @@
+package v1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// +genclient
+// +k8s:deepcopy-gen:interfaces=k8s.io/apimachinery/pkg/runtime.Object
+// +kubebuilder:object:root=true
+// +kubebuilder:subresource:status
+// +kubebuilder:printcolumn:name="Primary",type=string,JSONPath=`.status.conditions[?(@.type=="PrimaryElected")].message`,description="Pod currently elected primary"
+// +kubebuilder:printcolumn:name="Clustering Active",type=string,JSONPath=`.status.conditions[?(@.type=="ClusteringActive")].status`,description="Whether the local labeler believes it is driving the primary"
+// +kubebuilder:printcolumn:name="Age",type=date,JSONPath=".metadata.creationTimestamp"
+
+// MySQLCluster is the Schema for an InnoDB Cluster managed by this operator.
+type MySQLCluster struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   MySQLClusterSpec   `json:"spec,omitempty"`
+	Status MySQLClusterStatus `json:"status,omitempty"`
+}
+
+// MySQLClusterSpec is the desired state of a MySQLCluster.
+type MySQLClusterSpec struct {
+	// Members is the desired number of InnoDB Cluster members.
+	Members int32 `json:"members,omitempty"`
+}
+
+// MySQLClusterStatus is the observed state of a MySQLCluster, including the
+// conditions ClusterLabelerController publishes after every sync: see
+// ConditionPrimaryElected, ConditionClusteringActive, and
+// ConditionTopologyDegraded in the labeler package.
+type MySQLClusterStatus struct {
+	// Conditions holds PrimaryElected, ClusteringActive, and
+	// TopologyDegraded, keyed by type per meta.SetStatusCondition.
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// MySQLClusterList is a list of MySQLCluster.
+type MySQLClusterList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+
+	Items []MySQLCluster `json:"items"`
+}
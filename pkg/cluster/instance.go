@@ -0,0 +1,41 @@
+// Package cluster identifies the MySQL instance a controller is running
+// alongside and the InnoDB Cluster it belongs to.
+package cluster
+
+// ClusterMode describes how an InnoDB Cluster elects its primary member(s).
+type ClusterMode string
+
+const (
+	// ClusterModeSinglePrimary is the default InnoDB Cluster mode: exactly
+	// one member accepts writes at a time.
+	ClusterModeSinglePrimary ClusterMode = "single-primary"
+	// ClusterModeMultiPrimary allows every member to accept writes
+	// simultaneously, so more than one member may legitimately be labeled
+	// primary at once.
+	ClusterModeMultiPrimary ClusterMode = "multi-primary"
+)
+
+// Instance identifies the MySQL instance a controller is running alongside
+// and the MySQLCluster/InnoDB Cluster it belongs to.
+type Instance struct {
+	// Namespace is the Kubernetes Namespace the instance's Pod and parent
+	// MySQLCluster live in.
+	Namespace string
+	// ClusterName is the name of the parent MySQLCluster.
+	ClusterName string
+	// PodName is the name of the Pod the instance runs as, also its
+	// addressable hostname within the headless Service InnoDB Cluster uses
+	// for its seed list.
+	PodName string
+	// Port is the MySQL protocol port the instance listens on.
+	Port int
+	// ClusterMode is how the parent InnoDB Cluster elects primaries. Only
+	// in ClusterModeMultiPrimary can more than one topology member
+	// legitimately be labeled primary at once.
+	ClusterMode ClusterMode
+}
+
+// Name returns the instance's Pod name.
+func (i *Instance) Name() string {
+	return i.PodName
+}
@@ -3,10 +3,12 @@ package labeler
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/golang/glog"
 	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
 
 	corev1 "k8s.io/api/core/v1"
 	labels "k8s.io/apimachinery/pkg/labels"
@@ -21,7 +23,9 @@ import (
 	cluster "github.com/oracle/mysql-operator/pkg/cluster"
 	innodb "github.com/oracle/mysql-operator/pkg/cluster/innodb"
 	clusterctrl "github.com/oracle/mysql-operator/pkg/controllers/cluster"
+	"github.com/oracle/mysql-operator/pkg/controllers/cluster/labeler/metrics"
 	controllerutils "github.com/oracle/mysql-operator/pkg/controllers/util"
+	clientset "github.com/oracle/mysql-operator/pkg/generated/clientset/versioned"
 )
 
 const (
@@ -29,15 +33,42 @@ const (
 	LabelMySQLClusterRole = "v1.mysql.oracle.com/role"
 	// MySQLClusterRolePrimary denotes a primary InnoDB cluster member.
 	MySQLClusterRolePrimary = "primary"
-	// MySQLClusterRoleSecondary denotes a secondary InnoDB cluster member.
+	// MySQLClusterRoleSecondary denotes a secondary, ONLINE InnoDB cluster
+	// member that isn't lagging beyond replicationLagThreshold.
 	MySQLClusterRoleSecondary = "secondary"
+	// MySQLClusterRoleReadOnlyReplica denotes an ONLINE secondary that is
+	// lagging beyond replicationLagThreshold and shouldn't be routed reads
+	// that require fresh data.
+	MySQLClusterRoleReadOnlyReplica = "read-only-replica"
+	// MySQLClusterRoleRecovering denotes a member that the topology reports
+	// as RECOVERING, e.g. while distributed recovery is in progress.
+	MySQLClusterRoleRecovering = "recovering"
+	// MySQLClusterRoleOffline denotes a member present in the topology but
+	// not in an ONLINE, RECOVERING, or otherwise routable state.
+	MySQLClusterRoleOffline = "offline"
+
+	// AnnotationReplicationLagSeconds records the last known replication lag,
+	// in seconds, of a Pod's MySQL instance behind the primary. It's used by
+	// Services and external routers (ProxySQL, MySQL Router) to pick healthy
+	// read replicas.
+	AnnotationReplicationLagSeconds = "v1.mysql.oracle.com/replication-lag-seconds"
+
+	// replicationLagThreshold is the lag, in seconds, beyond which an ONLINE
+	// secondary is labeled MySQLClusterRoleReadOnlyReplica rather than
+	// MySQLClusterRoleSecondary.
+	replicationLagThreshold = 30
 
 	controllerAgentName = "innodb-cluster-labeler"
 )
 
 // ClusterLabelerController adds annotations about the InnoDB cluster state
-// to the MySQLCluster's Pods. This controller should only be run iff the the
-// local MySQL instance believes that it is the primary of the MySQL cluster.
+// to the MySQLCluster's Pods. It must only patch Pod labels while the local
+// MySQL instance believes that it is the primary of the MySQL cluster,
+// which Run enforces with a coordination.k8s.io Lease backstopped by
+// LeaderChecker: the Lease alone picks one campaign winner, and LeaderChecker
+// forces that winner to step down the moment its own view of leadership
+// disagrees with reality, so two Pods that simultaneously believe they are
+// primary during a network partition can't both end up patching labels.
 type ClusterLabelerController struct {
 	// localInstance represents the local MySQL instance.
 	localInstance *cluster.Instance
@@ -49,9 +80,29 @@ type ClusterLabelerController struct {
 	podListerSynced cache.InformerSynced
 	// podControl enables control of cluster Pods.
 	podControl clusterctrl.PodControlInterface
+	// clusterClient is used to publish status conditions back onto the
+	// parent MySQLCluster. It may be nil, in which case condition updates
+	// are skipped (e.g. in tests that don't need them).
+	clusterClient clientset.Interface
 
 	queue workqueue.RateLimitingInterface
 	store cache.Store
+
+	// eventQueue, if set via SetEventQueue, receives an Event for every role
+	// change this controller applies.
+	eventQueue workqueue.Interface
+
+	// newConnectionKiller builds the ConnectionKiller used to terminate
+	// client connections on a Pod being demoted from primary. It's a field
+	// so tests can stub it out.
+	newConnectionKiller func(podName string) ConnectionKiller
+
+	// kubeClient is used to acquire the coordination.k8s.io Lease that Run
+	// guards the worker loop with.
+	kubeClient kubernetes.Interface
+	// leaderChecker confirms the local instance still believes itself to be
+	// primary while this controller holds the Lease.
+	leaderChecker LeaderChecker
 }
 
 func keyFunc(obj interface{}) (string, error) {
@@ -66,32 +117,98 @@ func keyFunc(obj interface{}) (string, error) {
 func NewClusterLabelerController(
 	localInstance *cluster.Instance,
 	kubeClient kubernetes.Interface,
+	clusterClient clientset.Interface,
 	podInformer corev1informers.PodInformer,
 ) *ClusterLabelerController {
 	controller := &ClusterLabelerController{
-		localInstance:   localInstance,
-		podLister:       podInformer.Lister(),
-		podListerSynced: podInformer.Informer().HasSynced,
-		podControl:      clusterctrl.NewRealPodControl(kubeClient, podInformer.Lister()),
-		queue:           workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerAgentName),
-		store:           cache.NewStore(keyFunc),
+		localInstance:       localInstance,
+		podLister:           podInformer.Lister(),
+		podListerSynced:     podInformer.Informer().HasSynced,
+		podControl:          clusterctrl.NewRealPodControl(kubeClient, podInformer.Lister()),
+		clusterClient:       clusterClient,
+		queue:               workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), controllerAgentName),
+		store:               cache.NewStore(keyFunc),
+		newConnectionKiller: NewConnectionKiller,
+		kubeClient:          kubeClient,
+		leaderChecker:       NewLeaderChecker(localInstance),
 	}
 	return controller
 }
 
-func (clc *ClusterLabelerController) updateClusterRoleLabel(pod *corev1.Pod, val string) error {
+func (clc *ClusterLabelerController) updateClusterRoleLabel(pod *corev1.Pod, val, reason string) error {
+	return clc.updatePodRoleAndLag(pod, val, 0, reason)
+}
+
+// updatePodRoleAndLag relabels pod with the given role (or removes the role
+// label if val is "") and records lagSeconds in the
+// AnnotationReplicationLagSeconds annotation, so Services and external
+// routers can pick healthy, non-lagging read replicas. The annotation is
+// removed along with the label whenever val is "". reason is recorded on the
+// metrics.RelabelTotal counter to explain why the change happened.
+func (clc *ClusterLabelerController) updatePodRoleAndLag(pod *corev1.Pod, val string, lagSeconds int, reason string) error {
 	new := pod.DeepCopy()
+	fromRole := new.Labels[LabelMySQLClusterRole]
+	wasPrimary := fromRole == MySQLClusterRolePrimary
 	if val == "" {
 		delete(new.Labels, LabelMySQLClusterRole)
+		delete(new.Annotations, AnnotationReplicationLagSeconds)
 	} else {
 		new.Labels[LabelMySQLClusterRole] = val
+		if new.Annotations == nil {
+			new.Annotations = map[string]string{}
+		}
+		new.Annotations[AnnotationReplicationLagSeconds] = fmt.Sprintf("%d", lagSeconds)
+	}
+
+	demoting := wasPrimary && val != MySQLClusterRolePrimary
+	if demoting {
+		clc.demote(pod)
 	}
-	return clc.podControl.PatchPod(pod, new)
+
+	if err := clc.podControl.PatchPod(pod, new); err != nil {
+		return err
+	}
+
+	if demoting {
+		clc.demote(pod)
+	}
+	metrics.RelabelTotal.WithLabelValues(fromRole, val, reason).Inc()
+	clc.emitEvent(pod.Namespace, pod.Name, fromRole, val)
+	return nil
 }
 
-// syncHandler labels the Pods in a MySQLCluster as being either a primary or
-// secondary based on the given innodb.ClusterStatus.
+// demote kills client connections open against pod's MySQL instance, forcing
+// them to reconnect and discover the new primary rather than silently
+// continuing to write to what is now a read-only server. It's called once
+// as the demotion begins and again once the role label change has landed,
+// to close the window in which a client could open a new connection against
+// the demoted instance in between. It's best-effort and fire-and-forget: the
+// kill (with its own retry/backoff) runs in a goroutine so a temporarily
+// unreachable instance can't stall syncHandler's single worker goroutine,
+// and hence relabeling of every other Pod, for the duration of the backoff.
+func (clc *ClusterLabelerController) demote(pod *corev1.Pod) {
+	namespace, name := pod.Namespace, pod.Name
+	addr := fmt.Sprintf("%s:%d", name, clc.localInstance.Port)
+	killer := clc.newConnectionKiller(name)
+
+	go func() {
+		if err := killer.KillConnections(context.Background(), addr); err != nil {
+			glog.Warningf("Failed to kill client connections on demoted instance %s/%s: %v", namespace, name, err)
+		}
+	}()
+}
+
+// syncHandler labels the Pods in a MySQLCluster based on the given
+// innodb.ClusterStatus: in cluster.ClusterModeMultiPrimary every Pod the
+// topology reports as PRIMARY is labeled primary, while in single-primary
+// mode only clc.localInstance is, whatever the topology reports. The rest are
+// labeled secondary, read-only-replica, recovering, or offline according to
+// their own status.
 func (clc *ClusterLabelerController) syncHandler(key string) error {
+	clusterName := clc.localInstance.ClusterName
+	timer := prometheus.NewTimer(metrics.SyncDuration.WithLabelValues(clusterName))
+	defer timer.ObserveDuration()
+
 	obj, exists, err := clc.store.GetByKey(key)
 	if err != nil {
 		return errors.Wrapf(err, "getting key %q out of store", key)
@@ -103,7 +220,11 @@ func (clc *ClusterLabelerController) syncHandler(key string) error {
 	status := obj.(*innodb.ClusterStatus)
 
 	namespace := clc.localInstance.Namespace
-	clusterName := clc.localInstance.ClusterName
+
+	expectedPrimaries := map[string]bool{fmt.Sprintf("%s:%d", clc.localInstance.Name(), clc.localInstance.Port): true}
+	if clc.localInstance.ClusterMode == cluster.ClusterModeMultiPrimary {
+		expectedPrimaries = primaryMemberAddrs(status.DefaultReplicaSet.Topology)
+	}
 
 	// Get any Pods already labeled as primaries for this cluster.
 	primaries, err := clc.podLister.Pods(namespace).List(PrimarySelector(clusterName))
@@ -111,40 +232,46 @@ func (clc *ClusterLabelerController) syncHandler(key string) error {
 		return errors.Wrap(err, "failed to list primaries")
 	}
 
-	// Remove the mysql.oracle.com/role=primary label from any Pods that aren't
-	// the local primary.
-	primaryLabeled := false
+	// Remove the mysql.oracle.com/role=primary label from any Pods that
+	// aren't in expectedPrimaries.
+	primaryLabeled := map[string]bool{}
 	for _, pod := range primaries {
-		if pod.Name == clc.localInstance.Name() {
-			primaryLabeled = true
+		addr := fmt.Sprintf("%s:%d", pod.Name, clc.localInstance.Port)
+		if expectedPrimaries[addr] {
+			primaryLabeled[addr] = true
 			continue
 		}
 
-		var role string
-		if !inCluster(status, fmt.Sprintf("%s:%d", pod.Name, clc.localInstance.Port)) {
+		role, lagSeconds, present := memberRole(status, addr)
+		reason := "demoted"
+		if !present {
 			glog.Infof("Removing %q label from previously labeled primary %s/%s",
 				LabelMySQLClusterRole, pod.Namespace, pod.Name)
 			role = ""
+			reason = "left_topology"
 		} else {
-			glog.Infof("Labeling previously labeled primary %s/%s as secondary", pod.Namespace, pod.Name)
-			role = MySQLClusterRoleSecondary
+			glog.Infof("Labeling previously labeled primary %s/%s as %s", pod.Namespace, pod.Name, role)
 		}
 
-		if err := clc.updateClusterRoleLabel(pod, role); err != nil {
+		if err := clc.updatePodRoleAndLag(pod, role, lagSeconds, reason); err != nil {
 			return errors.Wrap(err, "relabeling primary")
 		}
 	}
 
-	// If the local primary is not yet labeled mysql.oracle.com/role=primary
-	// label it.
-	if !primaryLabeled {
-		primary, err := clc.podLister.Pods(namespace).Get(clc.localInstance.Name())
+	// Label every expected primary that isn't already labeled as one.
+	for addr := range expectedPrimaries {
+		if primaryLabeled[addr] {
+			continue
+		}
+
+		podName := strings.SplitN(addr, ":", 2)[0]
+		primary, err := clc.podLister.Pods(namespace).Get(podName)
 		if err != nil {
-			return errors.Wrap(err, "failed to get primary Pod")
+			return errors.Wrapf(err, "failed to get primary Pod %s", podName)
 		}
 
 		glog.Infof("Labeling %s/%s as primary", primary.Namespace, primary.Name)
-		if err := clc.updateClusterRoleLabel(primary, MySQLClusterRolePrimary); err != nil {
+		if err := clc.updateClusterRoleLabel(primary, MySQLClusterRolePrimary, "elected"); err != nil {
 			return errors.Wrapf(err, "labeling %s/%s as primary", primary.Namespace, primary.Name)
 		}
 	}
@@ -155,26 +282,70 @@ func (clc *ClusterLabelerController) syncHandler(key string) error {
 		return errors.Wrap(err, "failed to list non-primary Cluster pods")
 	}
 
-	// Ensure they are labeled as secondary or not at all.
+	// Ensure they carry the role label (and replication-lag annotation)
+	// matching their current topology status, or no role label at all if
+	// they've left the topology.
 	for _, pod := range pods {
-		if !inCluster(status, fmt.Sprintf("%s:%d", pod.Name, clc.localInstance.Port)) {
+		addr := fmt.Sprintf("%s:%d", pod.Name, clc.localInstance.Port)
+		if expectedPrimaries[addr] {
+			// Handled above: this Pod is being (or was just) labeled primary.
+			continue
+		}
+
+		role, lagSeconds, present := memberRole(status, addr)
+		if !present {
 			if HasRoleSelector(clusterName).Matches(labels.Set(pod.Labels)) {
-				glog.Infof("Removing %q label from %s/%s as it's no longer in an ONLINE state",
+				glog.Infof("Removing %q label from %s/%s as it's no longer part of the topology",
 					LabelMySQLClusterRole, pod.Namespace, pod.Name)
-				if err := clc.updateClusterRoleLabel(pod, ""); err != nil {
+				if err := clc.updateClusterRoleLabel(pod, "", "left_topology"); err != nil {
 					return errors.Wrapf(err, "removing %q label from %s/%s", LabelMySQLClusterRole, pod.Namespace, pod.Name)
 				}
 			}
 			continue
 		}
-		if pod.Name != clc.localInstance.Name() && !SecondarySelector(clusterName).Matches(labels.Set(pod.Labels)) {
-			glog.Infof("Labeling %s/%s as secondary", pod.Namespace, pod.Name)
-			if err := clc.updateClusterRoleLabel(pod, MySQLClusterRoleSecondary); err != nil {
-				return errors.Wrapf(err, "labeling %s/%s as secondary", pod.Namespace, pod.Name)
+		lagAnnotation := fmt.Sprintf("%d", lagSeconds)
+		if pod.Name != clc.localInstance.Name() &&
+			(pod.Labels[LabelMySQLClusterRole] != role || pod.Annotations[AnnotationReplicationLagSeconds] != lagAnnotation) {
+			glog.Infof("Labeling %s/%s as %s", pod.Namespace, pod.Name, role)
+			if err := clc.updatePodRoleAndLag(pod, role, lagSeconds, "topology_status_changed"); err != nil {
+				return errors.Wrapf(err, "labeling %s/%s as %s", pod.Namespace, pod.Name, role)
 			}
 		}
 	}
 
+	degraded := false
+	statusCounts := map[innodb.InstanceStatus]int{}
+	for _, inst := range status.DefaultReplicaSet.Topology {
+		statusCounts[inst.Status]++
+		if inst.Status != innodb.InstanceStatusOnline {
+			degraded = true
+		}
+	}
+	metrics.TopologyMembers.Reset()
+	for instStatus, count := range statusCounts {
+		metrics.TopologyMembers.WithLabelValues(clusterName, string(instStatus)).Set(float64(count))
+	}
+	// Report every Pod in expectedPrimaries: in ClusterModeMultiPrimary
+	// that's every topology member reported as PRIMARY, potentially several
+	// Pods, and in single-primary mode it's always the local instance.
+	metrics.CurrentPrimary.Reset()
+	for addr := range expectedPrimaries {
+		podName := strings.SplitN(addr, ":", 2)[0]
+		metrics.CurrentPrimary.WithLabelValues(clusterName, podName).Set(1)
+	}
+
+	localAddr := fmt.Sprintf("%s:%d", clc.localInstance.Name(), clc.localInstance.Port)
+	localIsPrimary := expectedPrimaries[localAddr]
+	var primaryName string
+	for addr := range expectedPrimaries {
+		primaryName = strings.SplitN(addr, ":", 2)[0]
+		break
+	}
+
+	if err := clc.updateConditions(context.Background(), status, primaryName, localIsPrimary, degraded); err != nil {
+		glog.Warningf("Failed to update MySQLCluster conditions: %v", err)
+	}
+
 	return nil
 }
 
@@ -200,6 +371,7 @@ func (clc *ClusterLabelerController) processNextWorkItem() bool {
 
 	if err != nil {
 		utilruntime.HandleError(fmt.Errorf("Error syncing cluster status: %+v", err))
+		metrics.WorkqueueRetriesTotal.WithLabelValues(clc.localInstance.ClusterName).Inc()
 		clc.queue.AddRateLimited(obj)
 	}
 
@@ -240,7 +412,21 @@ func (clc *ClusterLabelerController) Run(ctx context.Context) {
 		return
 	}
 
+	runClusterLabelerLeaseCampaign(ctx, clc.kubeClient, clc.localInstance, clc.runAsLeader, func() {
+		glog.Infof("Lost the %s/%s ClusterLabelerController Lease", clc.localInstance.Namespace, clc.localInstance.ClusterName)
+	})
+}
+
+// runAsLeader is invoked once this Pod has won the ClusterLabelerController
+// Lease. It starts the worker loop and, in parallel, keeps confirming via
+// LeaderChecker that the local instance still believes it is primary; should
+// that ever stop being true, watchLeadership calls stepDown, which both
+// stops the worker (ctx is a descendant of the ctx stepDown cancels) and
+// tells leaderelection.RunOrDie to release the Lease so another Pod can
+// acquire it.
+func (clc *ClusterLabelerController) runAsLeader(ctx context.Context, stepDown context.CancelFunc) {
 	glog.Info("Starting ClusterLabelerController controller worker")
+	go clc.watchLeadership(ctx, stepDown)
 	go wait.Until(clc.runWorker, time.Second, ctx.Done())
 
 	glog.Info("Started ClusterLabelerController controller worker")
@@ -248,10 +434,40 @@ func (clc *ClusterLabelerController) Run(ctx context.Context) {
 	<-ctx.Done()
 }
 
-// inCluster returns true if an instance with the given address is a functioning
-// member of the InnoDB cluster.
-func inCluster(status *innodb.ClusterStatus, address string) bool {
+// primaryMemberAddrs returns every address in topology whose MemberRole is
+// PRIMARY. Only meaningful in ClusterModeMultiPrimary: single-primary
+// topologies are expected to report exactly one, but syncHandler trusts
+// clc.localInstance rather than the topology in that mode, since only the
+// local instance can ever correctly label itself primary.
+func primaryMemberAddrs(topology map[string]innodb.Instance) map[string]bool {
+	addrs := map[string]bool{}
+	for addr, inst := range topology {
+		if inst.MemberRole == innodb.MemberRolePrimary {
+			addrs[addr] = true
+		}
+	}
+	return addrs
+}
+
+// memberRole maps the topology entry for address to the role label it should
+// carry and the replication lag (in seconds) that should be recorded
+// alongside it. present is false if address isn't part of the topology at
+// all, in which case any role label should be removed outright.
+func memberRole(status *innodb.ClusterStatus, address string) (role string, lagSeconds int, present bool) {
 	inst, ok := status.DefaultReplicaSet.Topology[address]
-	r := ok && (inst.Status == innodb.InstanceStatusOnline)
-	return r
+	if !ok {
+		return "", 0, false
+	}
+
+	switch inst.Status {
+	case innodb.InstanceStatusOnline:
+		if inst.ReplicationLagSeconds > replicationLagThreshold {
+			return MySQLClusterRoleReadOnlyReplica, inst.ReplicationLagSeconds, true
+		}
+		return MySQLClusterRoleSecondary, inst.ReplicationLagSeconds, true
+	case innodb.InstanceStatusRecovering:
+		return MySQLClusterRoleRecovering, inst.ReplicationLagSeconds, true
+	default:
+		return MySQLClusterRoleOffline, inst.ReplicationLagSeconds, true
+	}
 }
\ No newline at end of file
@@ -0,0 +1,89 @@
+package labeler
+
+import (
+	"testing"
+
+	innodb "github.com/oracle/mysql-operator/pkg/cluster/innodb"
+)
+
+func TestPrimaryMemberAddrsTwoPrimaries(t *testing.T) {
+	topology := map[string]innodb.Instance{
+		"mysql-0:3306": {Status: innodb.InstanceStatusOnline, MemberRole: innodb.MemberRolePrimary},
+		"mysql-1:3306": {Status: innodb.InstanceStatusOnline, MemberRole: innodb.MemberRolePrimary},
+		"mysql-2:3306": {Status: innodb.InstanceStatusOnline, MemberRole: innodb.MemberRoleSecondary},
+	}
+
+	got := primaryMemberAddrs(topology)
+
+	want := map[string]bool{"mysql-0:3306": true, "mysql-1:3306": true}
+	if len(got) != len(want) {
+		t.Fatalf("primaryMemberAddrs() = %v, want %v", got, want)
+	}
+	for addr := range want {
+		if !got[addr] {
+			t.Errorf("primaryMemberAddrs() missing expected primary %q, got %v", addr, got)
+		}
+	}
+}
+
+func TestPrimaryMemberAddrsThreePrimaries(t *testing.T) {
+	topology := map[string]innodb.Instance{
+		"mysql-0:3306": {Status: innodb.InstanceStatusOnline, MemberRole: innodb.MemberRolePrimary},
+		"mysql-1:3306": {Status: innodb.InstanceStatusOnline, MemberRole: innodb.MemberRolePrimary},
+		"mysql-2:3306": {Status: innodb.InstanceStatusOnline, MemberRole: innodb.MemberRolePrimary},
+	}
+
+	got := primaryMemberAddrs(topology)
+
+	if len(got) != 3 {
+		t.Fatalf("primaryMemberAddrs() = %v, want all 3 members", got)
+	}
+	for addr := range topology {
+		if !got[addr] {
+			t.Errorf("primaryMemberAddrs() missing expected primary %q, got %v", addr, got)
+		}
+	}
+}
+
+func TestPrimaryMemberAddrsNoPrimaries(t *testing.T) {
+	topology := map[string]innodb.Instance{
+		"mysql-0:3306": {Status: innodb.InstanceStatusRecovering, MemberRole: innodb.MemberRoleSecondary},
+	}
+
+	if got := primaryMemberAddrs(topology); len(got) != 0 {
+		t.Errorf("primaryMemberAddrs() = %v, want empty", got)
+	}
+}
+
+func TestMemberRole(t *testing.T) {
+	status := &innodb.ClusterStatus{
+		DefaultReplicaSet: innodb.ReplicaSet{
+			Topology: map[string]innodb.Instance{
+				"mysql-0:3306": {Status: innodb.InstanceStatusOnline, ReplicationLagSeconds: 0},
+				"mysql-1:3306": {Status: innodb.InstanceStatusOnline, ReplicationLagSeconds: replicationLagThreshold + 1},
+				"mysql-2:3306": {Status: innodb.InstanceStatusRecovering},
+				"mysql-3:3306": {Status: innodb.InstanceStatusOffline},
+			},
+		},
+	}
+
+	cases := []struct {
+		address     string
+		wantRole    string
+		wantPresent bool
+	}{
+		{"mysql-0:3306", MySQLClusterRoleSecondary, true},
+		{"mysql-1:3306", MySQLClusterRoleReadOnlyReplica, true},
+		{"mysql-2:3306", MySQLClusterRoleRecovering, true},
+		{"mysql-3:3306", MySQLClusterRoleOffline, true},
+		{"mysql-missing:3306", "", false},
+	}
+
+	for _, c := range cases {
+		role, _, present := memberRole(status, c.address)
+		if role != c.wantRole || present != c.wantPresent {
+			t.Errorf("memberRole(%q) = (%q, _, %v), want (%q, _, %v)",
+				c.address, role, present, c.wantRole, c.wantPresent)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package labeler
+
+import (
+	"context"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
+
+	innodb "github.com/oracle/mysql-operator/pkg/cluster/innodb"
+	clientset "github.com/oracle/mysql-operator/pkg/generated/clientset/versioned"
+)
+
+// Condition types published on MySQLCluster by the ClusterLabelerController,
+// modeled after moco's ConditionClusteringActive/ConditionReconciliationActive.
+const (
+	// ConditionPrimaryElected is true once a primary Pod has been elected
+	// and labeled for the cluster.
+	ConditionPrimaryElected = "PrimaryElected"
+	// ConditionClusteringActive is true while the local instance believes
+	// itself to be primary and the labeler is running its sync loop.
+	ConditionClusteringActive = "ClusteringActive"
+	// ConditionTopologyDegraded is true when any member of
+	// status.DefaultReplicaSet.Topology is not ONLINE.
+	ConditionTopologyDegraded = "TopologyDegraded"
+)
+
+// updateConditions patches the parent MySQLCluster's status conditions to
+// reflect the outcome of a single syncHandler run, so operators have a
+// first-class signal for failover progress instead of having to inspect pod
+// labels. primary names a Pod currently elected primary (for the
+// PrimaryElected message only; any one of several suffices in
+// ClusterModeMultiPrimary) and localIsPrimary reports whether
+// clc.localInstance itself is among the elected primaries.
+func (clc *ClusterLabelerController) updateConditions(ctx context.Context, status *innodb.ClusterStatus, primary string, localIsPrimary bool, degraded bool) error {
+	if clc.clusterClient == nil {
+		return nil
+	}
+
+	namespace := clc.localInstance.Namespace
+	clusterName := clc.localInstance.ClusterName
+
+	cluster, err := clc.clusterClient.MysqlV1().MySQLClusters(namespace).Get(ctx, clusterName, metav1.GetOptions{})
+	if err != nil {
+		return errors.Wrapf(err, "getting MySQLCluster %s/%s", namespace, clusterName)
+	}
+	updated := cluster.DeepCopy()
+
+	if primary != "" {
+		meta.SetStatusCondition(&updated.Status.Conditions, metav1.Condition{
+			Type:    ConditionPrimaryElected,
+			Status:  metav1.ConditionTrue,
+			Reason:  "PrimaryLabeled",
+			Message: "Pod " + primary + " is labeled as primary",
+		})
+	}
+
+	clusteringActive := metav1.ConditionFalse
+	reason := "NotPrimary"
+	if localIsPrimary {
+		clusteringActive = metav1.ConditionTrue
+		reason = "LocalInstancePrimary"
+	}
+	meta.SetStatusCondition(&updated.Status.Conditions, metav1.Condition{
+		Type:    ConditionClusteringActive,
+		Status:  clusteringActive,
+		Reason:  reason,
+		Message: "ClusterLabelerController sync loop status",
+	})
+
+	topologyDegraded := metav1.ConditionFalse
+	degradedReason := "TopologyHealthy"
+	degradedMessage := "All expected members are ONLINE"
+	if degraded {
+		topologyDegraded = metav1.ConditionTrue
+		degradedReason = "MemberNotOnline"
+		degradedMessage = "One or more expected members are not ONLINE"
+	}
+	meta.SetStatusCondition(&updated.Status.Conditions, metav1.Condition{
+		Type:    ConditionTopologyDegraded,
+		Status:  topologyDegraded,
+		Reason:  degradedReason,
+		Message: degradedMessage,
+	})
+
+	if _, err := clc.clusterClient.MysqlV1().MySQLClusters(namespace).UpdateStatus(ctx, updated, metav1.UpdateOptions{}); err != nil {
+		return errors.Wrapf(err, "updating conditions on MySQLCluster %s/%s", namespace, clusterName)
+	}
+
+	glog.V(4).Infof("Updated conditions on MySQLCluster %s/%s", namespace, clusterName)
+	return nil
+}
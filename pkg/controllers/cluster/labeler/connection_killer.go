@@ -0,0 +1,119 @@
+package labeler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+	"github.com/prometheus/client_golang/prometheus"
+
+	wait "k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/oracle/mysql-operator/pkg/mysqlconn"
+)
+
+// systemUsers are never killed, even if they hold open connections against a
+// demoted instance, so that the operator's own health checks and replication
+// channels are left alone.
+var systemUsers = map[string]bool{
+	"root":            true,
+	"system user":     true,
+	"event_scheduler": true,
+}
+
+var killedConnectionsTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "mysql_operator_labeler_killed_connections_total",
+		Help: "Number of client connections killed on a Pod by the ClusterLabelerController after a role change.",
+	},
+	[]string{"pod"},
+)
+
+func init() {
+	prometheus.MustRegister(killedConnectionsTotal)
+}
+
+// ConnectionKiller terminates client connections held open against a MySQL
+// instance, forcing clients to reconnect and pick up the current primary
+// rather than silently continuing to write to a demoted, read-only server.
+type ConnectionKiller interface {
+	// KillConnections terminates every non-system client connection on the
+	// MySQL instance reachable at addr.
+	KillConnections(ctx context.Context, addr string) error
+}
+
+// processlistConnectionKiller implements ConnectionKiller on top of
+// information_schema.PROCESSLIST and KILL, retrying with backoff so that a
+// temporarily unreachable instance doesn't block relabeling of other Pods.
+type processlistConnectionKiller struct {
+	// dial opens a connection to the instance at addr. It's a field so tests
+	// can substitute a fake implementation.
+	dial func(addr string) (*sql.DB, error)
+
+	// pod is the name of the Pod whose connections are being killed, used
+	// only to label the killedConnectionsTotal metric.
+	pod string
+}
+
+// NewConnectionKiller returns a ConnectionKiller that kills connections on
+// the Pod named podName via the standard MySQL protocol.
+func NewConnectionKiller(podName string) ConnectionKiller {
+	return &processlistConnectionKiller{dial: mysqlconn.Dial, pod: podName}
+}
+
+func (k *processlistConnectionKiller) KillConnections(ctx context.Context, addr string) error {
+	backoff := wait.Backoff{Duration: 500 * time.Millisecond, Factor: 2.0, Steps: 5}
+
+	return wait.ExponentialBackoff(backoff, func() (bool, error) {
+		if err := k.killConnectionsOnce(ctx, addr); err != nil {
+			glog.Warningf("Failed to kill connections on %s, will retry: %v", addr, err)
+			return false, nil
+		}
+		return true, nil
+	})
+}
+
+func (k *processlistConnectionKiller) killConnectionsOnce(ctx context.Context, addr string) error {
+	db, err := k.dial(addr)
+	if err != nil {
+		return errors.Wrapf(err, "connecting to %s", addr)
+	}
+	defer db.Close()
+
+	rows, err := db.QueryContext(ctx, "SELECT id, user FROM information_schema.PROCESSLIST")
+	if err != nil {
+		return errors.Wrapf(err, "listing processes on %s", addr)
+	}
+	defer rows.Close()
+
+	var ids []int64
+	for rows.Next() {
+		var id int64
+		var user string
+		if err := rows.Scan(&id, &user); err != nil {
+			return errors.Wrap(err, "scanning PROCESSLIST row")
+		}
+		if systemUsers[user] {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	if err := rows.Err(); err != nil {
+		return errors.Wrap(err, "iterating PROCESSLIST")
+	}
+
+	var killed int
+	for _, id := range ids {
+		if _, err := db.ExecContext(ctx, fmt.Sprintf("KILL %d", id)); err != nil {
+			glog.Warningf("Failed to kill connection %d on %s: %v", id, addr, err)
+			continue
+		}
+		killed++
+	}
+
+	killedConnectionsTotal.WithLabelValues(k.pod).Add(float64(killed))
+	return nil
+}
@@ -0,0 +1,43 @@
+package labeler
+
+import (
+	workqueue "k8s.io/client-go/util/workqueue"
+)
+
+// Event describes a role transition that ClusterLabelerController observed
+// and applied to a Pod. It's pushed onto an external workqueue (if one is
+// configured) so that other controllers — notably the NodeController — can
+// react to labeling decisions without re-deriving them from raw
+// innodb.ClusterStatus themselves.
+type Event struct {
+	// Namespace and ClusterName identify the MySQLCluster the Pod belongs to.
+	Namespace   string
+	ClusterName string
+	// PodName is the Pod whose role changed.
+	PodName string
+	// FromRole and ToRole are the previous and new values of
+	// LabelMySQLClusterRole (empty when the Pod had/now has no role label).
+	FromRole string
+	ToRole   string
+}
+
+// SetEventQueue configures a workqueue that clc pushes an Event onto whenever
+// it relabels a Pod. It's nil by default, in which case event emission is a
+// no-op; controllers.NewClusterAndNodeControllers installs the workqueue its
+// NodeController consumes.
+func (clc *ClusterLabelerController) SetEventQueue(q workqueue.Interface) {
+	clc.eventQueue = q
+}
+
+func (clc *ClusterLabelerController) emitEvent(podNamespace, podName, from, to string) {
+	if clc.eventQueue == nil {
+		return
+	}
+	clc.eventQueue.Add(Event{
+		Namespace:   podNamespace,
+		ClusterName: clc.localInstance.ClusterName,
+		PodName:     podName,
+		FromRole:    from,
+		ToRole:      to,
+	})
+}
@@ -0,0 +1,168 @@
+package labeler
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	kubernetes "k8s.io/client-go/kubernetes"
+	leaderelection "k8s.io/client-go/tools/leaderelection"
+	resourcelock "k8s.io/client-go/tools/leaderelection/resourcelock"
+
+	cluster "github.com/oracle/mysql-operator/pkg/cluster"
+	"github.com/oracle/mysql-operator/pkg/mysqlconn"
+)
+
+// leaderCheckPeriod is how often a held Lease is re-validated against
+// LeaderChecker while ClusterLabelerController is running.
+const leaderCheckPeriod = 5 * time.Second
+
+// leaseDuration, renewDeadline, and retryPeriod are the coordination.k8s.io
+// Lease timings for the ClusterLabelerController Lease, shared by both the
+// workqueue-based Run and the controller-runtime-based Reconciler.Start so
+// the two campaigns can't drift out of sync with each other.
+const (
+	leaseDuration = 15 * time.Second
+	renewDeadline = 10 * time.Second
+	retryPeriod   = 2 * time.Second
+)
+
+// newClusterLabelerLeaseConfig builds the leaderelection.LeaderElectionConfig
+// both Run and Reconciler.Start pass to leaderelection.RunOrDie to campaign
+// for the same per-MySQLCluster Lease, differing only in callbacks.
+func newClusterLabelerLeaseConfig(kubeClient kubernetes.Interface, localInstance *cluster.Instance, callbacks leaderelection.LeaderCallbacks) leaderelection.LeaderElectionConfig {
+	return leaderelection.LeaderElectionConfig{
+		Lock: &resourcelock.LeaseLock{
+			LeaseMeta: metav1.ObjectMeta{
+				Name:      localInstance.ClusterName,
+				Namespace: localInstance.Namespace,
+			},
+			Client: kubeClient.CoordinationV1(),
+			LockConfig: resourcelock.ResourceLockConfig{
+				Identity: localInstance.Name(),
+			},
+		},
+		LeaseDuration: leaseDuration,
+		RenewDeadline: renewDeadline,
+		RetryPeriod:   retryPeriod,
+		// ReleaseOnCancel makes a cancelled ctx actively update the Lease to
+		// give up this Pod's hold on it, rather than leaving its identity
+		// recorded as holder until LeaseDuration naturally elapses. Without
+		// it, stepping down on a local leadership disagreement would still
+		// block another Pod from acquiring the Lease for up to
+		// leaseDuration, defeating the point of stepping down promptly.
+		ReleaseOnCancel: true,
+		Callbacks:       callbacks,
+	}
+}
+
+// runClusterLabelerLeaseCampaign campaigns for the ClusterLabelerController
+// Lease until ctx is done, calling onStarted once this Pod wins it and
+// onStopped once it no longer holds it (whether because it stepped down or
+// because the Lease was lost to the API server). It's shared by Run and
+// Reconciler.Start so the two paths can't drift in how the Lease is
+// constructed, renewed, or released.
+//
+// onStarted is handed stepDown, which it should pass to watchLeadership (or
+// call directly): cancelling stepDown cancels the same ctx RunOrDie renews
+// the Lease against, so it actually releases the Lease rather than merely
+// ending onStarted's own goroutine while renewal continues underneath it.
+func runClusterLabelerLeaseCampaign(ctx context.Context, kubeClient kubernetes.Interface, localInstance *cluster.Instance, onStarted func(leaseCtx context.Context, stepDown context.CancelFunc), onStopped func()) {
+	ctx, stepDown := context.WithCancel(ctx)
+	defer stepDown()
+
+	leaderelection.RunOrDie(ctx, newClusterLabelerLeaseConfig(kubeClient, localInstance, leaderelection.LeaderCallbacks{
+		OnStartedLeading: func(leaseCtx context.Context) {
+			onStarted(leaseCtx, stepDown)
+		},
+		OnStoppedLeading: onStopped,
+	}))
+}
+
+// LeaderChecker reports whether the local MySQL instance currently believes
+// itself to be the primary of its Group Replication group. It backstops the
+// coordination.k8s.io Lease: the Lease alone only proves one Pod won the
+// campaign, not that the local instance actually sees itself as primary.
+type LeaderChecker interface {
+	// IsLeader returns true if the local instance believes it is primary.
+	IsLeader(ctx context.Context) (bool, error)
+}
+
+// groupReplicationLeaderChecker implements LeaderChecker by resolving
+// group_replication_primary_member (a server UUID) to its MEMBER_HOST via
+// performance_schema.replication_group_members and comparing that against
+// localInstance.Name().
+type groupReplicationLeaderChecker struct {
+	localInstance *cluster.Instance
+	dial          func() (*sql.DB, error)
+}
+
+// NewLeaderChecker returns the default LeaderChecker, which queries the
+// local instance directly.
+func NewLeaderChecker(localInstance *cluster.Instance) LeaderChecker {
+	return &groupReplicationLeaderChecker{
+		localInstance: localInstance,
+		dial: func() (*sql.DB, error) {
+			return mysqlconn.Dial(fmt.Sprintf("%s:%d", localInstance.Name(), localInstance.Port))
+		},
+	}
+}
+
+// primaryMemberHostQuery resolves group_replication_primary_member (a server
+// UUID) to the MEMBER_HOST of the matching row in
+// performance_schema.replication_group_members, since the status variable
+// alone can't be compared directly against a Pod/instance name.
+const primaryMemberHostQuery = `
+SELECT m.MEMBER_HOST
+FROM performance_schema.replication_group_members m
+JOIN performance_schema.global_status s
+  ON s.VARIABLE_NAME = 'group_replication_primary_member'
+  AND m.MEMBER_ID = s.VARIABLE_VALUE`
+
+func (c *groupReplicationLeaderChecker) IsLeader(ctx context.Context) (bool, error) {
+	db, err := c.dial()
+	if err != nil {
+		return false, errors.Wrap(err, "connecting to local instance")
+	}
+	defer db.Close()
+
+	var primaryHost string
+	row := db.QueryRowContext(ctx, primaryMemberHostQuery)
+	if err := row.Scan(&primaryHost); err != nil {
+		return false, errors.Wrap(err, "resolving group_replication_primary_member to a member host")
+	}
+	return primaryHost == c.localInstance.Name(), nil
+}
+
+// watchLeadership polls clc.leaderChecker every leaderCheckPeriod and cancels
+// stepDown as soon as the local instance stops believing it is primary,
+// e.g. because of a network partition that left it in a minority group.
+// Combined with the coordination.k8s.io Lease acquired in Run, this prevents
+// two Pods that simultaneously believe they are primary from both patching
+// Pod labels during a split-brain: the Lease picks one campaign winner, and
+// this check forces that winner to step down the moment its own view of
+// leadership disagrees with reality.
+func (clc *ClusterLabelerController) watchLeadership(ctx context.Context, stepDown context.CancelFunc) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(leaderCheckPeriod):
+			isLeader, err := clc.leaderChecker.IsLeader(ctx)
+			if err != nil {
+				glog.Warningf("Failed to confirm local leadership, assuming it still holds: %v", err)
+				continue
+			}
+			if !isLeader {
+				glog.Warningf("Local instance %s no longer believes it is primary; stepping down as ClusterLabelerController leader", clc.localInstance.Name())
+				stepDown()
+				return
+			}
+		}
+	}
+}
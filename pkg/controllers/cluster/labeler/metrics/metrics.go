@@ -0,0 +1,88 @@
+// Package metrics registers the Prometheus collectors published by
+// ClusterLabelerController, so operators can alert on conditions like "no
+// Pod is labeled primary" or "the labeler is looping" instead of having to
+// poll Pod labels.
+package metrics
+
+import (
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	// RelabelTotal counts every relabel operation the controller performs,
+	// partitioned by the role it moved from, the role it moved to (the empty
+	// string denotes "no role"), and why.
+	RelabelTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "mysql_operator",
+			Subsystem: "labeler",
+			Name:      "relabel_total",
+			Help:      "Number of Pod role relabel operations performed by the ClusterLabelerController.",
+		},
+		[]string{"from_role", "to_role", "reason"},
+	)
+
+	// CurrentPrimary reports, for each cluster, every Pod currently labeled
+	// primary via a constant gauge value of 1, keyed by pod name. It
+	// is reset and re-set on every sync so a stale value doesn't linger.
+	CurrentPrimary = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "mysql_operator",
+			Subsystem: "labeler",
+			Name:      "current_primary_info",
+			Help:      "Value is always 1; the pod label identifies the Pod currently labeled primary.",
+		},
+		[]string{"cluster", "pod"},
+	)
+
+	// SyncDuration observes how long syncHandler takes to run, in seconds.
+	SyncDuration = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Namespace: "mysql_operator",
+			Subsystem: "labeler",
+			Name:      "sync_duration_seconds",
+			Help:      "Time taken by ClusterLabelerController.syncHandler to run.",
+			Buckets:   prometheus.DefBuckets,
+		},
+		[]string{"cluster"},
+	)
+
+	// WorkqueueRetriesTotal counts how many times a workqueue item was
+	// re-queued after a failed syncHandler call.
+	WorkqueueRetriesTotal = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Namespace: "mysql_operator",
+			Subsystem: "labeler",
+			Name:      "workqueue_retries_total",
+			Help:      "Number of times a cluster status sync was retried after failing.",
+		},
+		[]string{"cluster"},
+	)
+
+	// TopologyMembers reports len(status.DefaultReplicaSet.Topology) split by
+	// innodb.InstanceStatus, so "how many members are ONLINE/OFFLINE/etc" is
+	// directly queryable.
+	TopologyMembers = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Namespace: "mysql_operator",
+			Subsystem: "labeler",
+			Name:      "topology_members",
+			Help:      "Number of InnoDB Cluster topology members, by instance status.",
+		},
+		[]string{"cluster", "status"},
+	)
+)
+
+func init() {
+	prometheus.MustRegister(RelabelTotal, CurrentPrimary, SyncDuration, WorkqueueRetriesTotal, TopologyMembers)
+}
+
+// Handler returns the http.Handler to mount at the agent's /metrics
+// endpoint. It serves the default Prometheus registry, which these
+// collectors register themselves into on import.
+func Handler() http.Handler {
+	return promhttp.Handler()
+}
@@ -0,0 +1,162 @@
+package labeler
+
+import (
+	"context"
+	"sync/atomic"
+
+	"github.com/go-logr/logr"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+)
+
+// statusEventsBufferSize bounds how many not-yet-reconciled cluster status
+// updates queue up before EnqueueClusterStatus starts coalescing them. It's
+// sized generously relative to the expected number of MySQLClusters a given
+// agent instance tracks (normally one) so dropping is only ever a coalesce
+// of updates for the same cluster, never a different one.
+const statusEventsBufferSize = 64
+
+// Reconciler ports ClusterLabelerController onto controller-runtime's
+// Manager/Reconciler model, so it can be composed with other controllers,
+// share their caches, and use structured logging (logr) instead of glog.
+// It wraps rather than replaces ClusterLabelerController: syncHandler, the
+// role/condition/metrics logic and the cache.Store it reads from are all
+// unchanged, only how a sync gets triggered changes. It also carries over
+// the coordination.k8s.io Lease that guards Run, via its own Start
+// method, so the same split-brain guard applies regardless of which path a
+// deployment runs.
+type Reconciler struct {
+	*ClusterLabelerController
+	Log logr.Logger
+
+	// statusEvents feeds the source.Channel watch SetupWithManager
+	// registers, so innodb.ClusterStatus updates pushed through
+	// EnqueueClusterStatus turn into Reconcile calls the same way Pod
+	// watches do.
+	statusEvents chan event.GenericEvent
+
+	// leading is 1 while this Pod holds the same ClusterLabelerController
+	// Lease the workqueue-based Run/runAsLeader path campaigns for, and 0
+	// otherwise. Start runs that campaign; Reconcile refuses to sync unless
+	// it's 1, so porting a deployment onto Reconciler doesn't reopen the
+	// split-brain window the Lease was added to close.
+	leading int32
+}
+
+// NewReconciler wraps clc as a controller-runtime Reconciler.
+func NewReconciler(clc *ClusterLabelerController, log logr.Logger) *Reconciler {
+	return &Reconciler{
+		ClusterLabelerController: clc,
+		Log:                      log,
+		statusEvents:             make(chan event.GenericEvent, statusEventsBufferSize),
+	}
+}
+
+// Reconcile re-syncs the MySQLCluster named by req, exactly as the
+// workqueue-based controller's processNextWorkItem drove syncHandler off a
+// key popped from clc.queue. It refuses to run unless Start has confirmed
+// this Pod holds the ClusterLabelerController Lease, mirroring how
+// runWorker only ever runs inside runAsLeader.
+func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
+	log := r.Log.WithValues("cluster", req.Name)
+
+	if atomic.LoadInt32(&r.leading) == 0 {
+		log.Info("not holding the ClusterLabelerController Lease, skipping sync")
+		return ctrl.Result{RequeueAfter: leaderCheckPeriod}, nil
+	}
+
+	if err := r.syncHandler(req.Name); err != nil {
+		log.Error(err, "failed to sync cluster status")
+		return ctrl.Result{}, err
+	}
+	return ctrl.Result{}, nil
+}
+
+// Start runs the same coordination.k8s.io Lease campaign
+// ClusterLabelerController.Run does, independently of controller-runtime's
+// own manager-level leader election (which SetupWithManager's caller may or
+// may not have configured). SetupWithManager registers Start as a
+// manager.Runnable so the Manager starts it alongside the controller.
+func (r *Reconciler) Start(ctx context.Context) error {
+	runClusterLabelerLeaseCampaign(ctx, r.kubeClient, r.localInstance,
+		func(leaseCtx context.Context, stepDown context.CancelFunc) {
+			atomic.StoreInt32(&r.leading, 1)
+			r.watchLeadership(leaseCtx, stepDown)
+		},
+		func() {
+			atomic.StoreInt32(&r.leading, 0)
+			r.Log.Info("lost the ClusterLabelerController Lease")
+		},
+	)
+	return nil
+}
+
+// EnqueueClusterStatus is a thin adapter over the legacy
+// ClusterLabelerController.EnqueueClusterStatus: it stores obj in the same
+// cache.Store, then pushes a source.Channel event so controller-runtime
+// schedules a Reconcile instead of relying on the old workqueue directly.
+// Existing callers that only know about EnqueueClusterStatus keep working
+// unmodified during the transition to controller-runtime.
+//
+// The send to statusEvents is non-blocking: Reconcile always re-reads the
+// latest status for the key out of r.store rather than off the event
+// itself, so a full channel only ever means a reconcile for that same key
+// is already pending, never a missed update. Blocking here instead would
+// risk deadlocking EnqueueClusterStatus's caller if the manager's channel
+// source hasn't started consuming yet.
+func (r *Reconciler) EnqueueClusterStatus(obj interface{}) error {
+	key, err := keyFunc(obj)
+	if err != nil {
+		return err
+	}
+	if err := r.store.Add(obj); err != nil {
+		return err
+	}
+
+	select {
+	case r.statusEvents <- event.GenericEvent{Object: &corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: key}}}:
+	default:
+		r.Log.Info("statusEvents channel full, relying on an already-pending reconcile", "cluster", key)
+	}
+	return nil
+}
+
+// SetupWithManager registers the Reconciler with mgr: it watches Pods
+// carrying the LabelMySQLCluster label, mapped onto a Reconcile request
+// keyed by that label's value (the same ClusterName key syncHandler's
+// r.store and keyFunc use — NOT the Pod's own name), and a source.Channel
+// fed by EnqueueClusterStatus for innodb.ClusterStatus updates.
+func (r *Reconciler) SetupWithManager(mgr manager.Manager) error {
+	hasClusterLabel := predicate.NewPredicateFuncs(func(obj client.Object) bool {
+		_, ok := obj.GetLabels()[LabelMySQLCluster]
+		return ok
+	})
+
+	mapPodToCluster := handler.EnqueueRequestsFromMapFunc(func(ctx context.Context, obj client.Object) []ctrl.Request {
+		clusterName, ok := obj.GetLabels()[LabelMySQLCluster]
+		if !ok {
+			return nil
+		}
+		return []ctrl.Request{{NamespacedName: types.NamespacedName{Name: clusterName}}}
+	})
+
+	if err := mgr.Add(r); err != nil {
+		return err
+	}
+
+	return ctrl.NewControllerManagedBy(mgr).
+		Watches(&corev1.Pod{}, mapPodToCluster, builder.WithPredicates(hasClusterLabel)).
+		Watches(&source.Channel{Source: r.statusEvents}, &handler.EnqueueRequestForObject{}).
+		Complete(r)
+}
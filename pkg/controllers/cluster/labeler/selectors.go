@@ -0,0 +1,61 @@
+package labeler
+
+import (
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+// LabelMySQLCluster identifies the MySQLCluster a Pod belongs to.
+const LabelMySQLCluster = "v1.mysql.oracle.com/cluster"
+
+func clusterSelector(clusterName string) labels.Selector {
+	return labels.SelectorFromSet(labels.Set{LabelMySQLCluster: clusterName})
+}
+
+func roleRequirement(op selection.Operator, values ...string) labels.Requirement {
+	req, err := labels.NewRequirement(LabelMySQLClusterRole, op, values)
+	if err != nil {
+		// Only hit if values is malformed, which never happens for the
+		// constant role names passed in by this package.
+		panic(err)
+	}
+	return *req
+}
+
+// HasRoleSelector selects Pods of clusterName that carry any
+// LabelMySQLClusterRole value at all.
+func HasRoleSelector(clusterName string) labels.Selector {
+	return clusterSelector(clusterName).Add(roleRequirement(selection.Exists))
+}
+
+// PrimarySelector selects Pods of clusterName labeled as primary.
+func PrimarySelector(clusterName string) labels.Selector {
+	return clusterSelector(clusterName).Add(roleRequirement(selection.Equals, MySQLClusterRolePrimary))
+}
+
+// SecondarySelector selects Pods of clusterName labeled as secondary.
+func SecondarySelector(clusterName string) labels.Selector {
+	return clusterSelector(clusterName).Add(roleRequirement(selection.Equals, MySQLClusterRoleSecondary))
+}
+
+// NonPrimarySelector selects Pods of clusterName that aren't labeled primary,
+// whether or not they carry another role label.
+func NonPrimarySelector(clusterName string) labels.Selector {
+	return clusterSelector(clusterName).Add(roleRequirement(selection.NotEquals, MySQLClusterRolePrimary))
+}
+
+// ReadOnlyReplicaSelector selects Pods of clusterName labeled as lagging
+// read-only replicas.
+func ReadOnlyReplicaSelector(clusterName string) labels.Selector {
+	return clusterSelector(clusterName).Add(roleRequirement(selection.Equals, MySQLClusterRoleReadOnlyReplica))
+}
+
+// RecoveringSelector selects Pods of clusterName labeled as recovering.
+func RecoveringSelector(clusterName string) labels.Selector {
+	return clusterSelector(clusterName).Add(roleRequirement(selection.Equals, MySQLClusterRoleRecovering))
+}
+
+// OfflineSelector selects Pods of clusterName labeled as offline.
+func OfflineSelector(clusterName string) labels.Selector {
+	return clusterSelector(clusterName).Add(roleRequirement(selection.Equals, MySQLClusterRoleOffline))
+}
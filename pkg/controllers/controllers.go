@@ -0,0 +1,54 @@
+// Package controllers wires together the controllers the agent binary runs
+// against a single MySQL instance.
+package controllers
+
+import (
+	"context"
+
+	workqueue "k8s.io/client-go/util/workqueue"
+
+	cluster "github.com/oracle/mysql-operator/pkg/cluster"
+	innodb "github.com/oracle/mysql-operator/pkg/cluster/innodb"
+	labeler "github.com/oracle/mysql-operator/pkg/controllers/cluster/labeler"
+	node "github.com/oracle/mysql-operator/pkg/controllers/node"
+)
+
+// nodeControllerAgentName names the workqueue shared between
+// ClusterLabelerController and NodeController.
+const nodeControllerAgentName = "innodb-node-controller"
+
+// NewClusterAndNodeControllers builds a ClusterLabelerController and its
+// peer NodeController, wired together over a shared workqueue: every role
+// change the labeler applies is pushed as a labeler.Event the NodeController
+// consumes, and every innodb.ClusterStatus update is fed to both so the
+// NodeController can also rejoin MISSING members. Run starts both; the
+// agent binary calls this once per MySQL instance and forwards each
+// innodb.ClusterStatus it observes to the returned EnqueueClusterStatus
+// closure, which enqueues it on both controllers.
+func NewClusterAndNodeControllers(
+	clc *labeler.ClusterLabelerController,
+	localInstance *cluster.Instance,
+	configurator node.InstanceConfigurator,
+) (nc *node.NodeController, enqueueClusterStatus func(interface{}) error) {
+	sharedQueue := workqueue.NewNamedRateLimitingQueue(workqueue.DefaultControllerRateLimiter(), nodeControllerAgentName)
+	clc.SetEventQueue(sharedQueue)
+
+	nc = node.NewNodeController(localInstance, configurator, sharedQueue)
+
+	enqueueClusterStatus = func(obj interface{}) error {
+		if err := clc.EnqueueClusterStatus(obj); err != nil {
+			return err
+		}
+		if status, ok := obj.(*innodb.ClusterStatus); ok {
+			nc.EnqueueClusterStatus(status)
+		}
+		return nil
+	}
+	return nc, enqueueClusterStatus
+}
+
+// Run starts clc and nc and blocks until ctx is cancelled.
+func Run(ctx context.Context, clc *labeler.ClusterLabelerController, nc *node.NodeController) {
+	go clc.Run(ctx)
+	nc.Run(ctx)
+}
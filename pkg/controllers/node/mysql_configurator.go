@@ -0,0 +1,87 @@
+package node
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/pkg/errors"
+
+	"github.com/oracle/mysql-operator/pkg/mysqlconn"
+)
+
+// sqlInstanceConfigurator implements InstanceConfigurator against the local
+// MySQL instance over the standard protocol.
+type sqlInstanceConfigurator struct {
+	// dial opens a *sql.DB for the local instance. It's a field so tests can
+	// substitute a fake implementation.
+	dial func() (*sql.DB, error)
+}
+
+// NewInstanceConfigurator returns an InstanceConfigurator that drives the
+// MySQL instance listening on localAddr.
+func NewInstanceConfigurator(localAddr string) InstanceConfigurator {
+	return &sqlInstanceConfigurator{
+		dial: func() (*sql.DB, error) {
+			return mysqlconn.Dial(localAddr)
+		},
+	}
+}
+
+func (c *sqlInstanceConfigurator) SetSuperReadOnly(ctx context.Context, enabled bool) error {
+	db, err := c.dial()
+	if err != nil {
+		return errors.Wrap(err, "connecting to local instance")
+	}
+	defer db.Close()
+
+	val := "OFF"
+	if enabled {
+		val = "ON"
+	}
+	if _, err := db.ExecContext(ctx, fmt.Sprintf("SET GLOBAL super_read_only = %s", val)); err != nil {
+		return errors.Wrapf(err, "setting super_read_only = %s", val)
+	}
+	return nil
+}
+
+func (c *sqlInstanceConfigurator) StartGroupReplication(ctx context.Context) error {
+	return c.exec(ctx, "START GROUP_REPLICATION")
+}
+
+func (c *sqlInstanceConfigurator) StopGroupReplication(ctx context.Context) error {
+	return c.exec(ctx, "STOP GROUP_REPLICATION")
+}
+
+// RejoinInstance re-joins addr to the group. dba.rejoinInstance() is an
+// AdminAPI call normally issued through MySQL Shell; START GROUP_REPLICATION
+// against the MISSING member achieves the same effect at the protocol level
+// once its group_replication_group_seeds already point at the live members.
+// Unlike the other InstanceConfigurator methods, this dials addr directly
+// rather than c.dial's local instance, since the whole point is to revive a
+// remote member that the local instance's own connection can't reach.
+func (c *sqlInstanceConfigurator) RejoinInstance(ctx context.Context, addr string) error {
+	db, err := mysqlconn.Dial(addr)
+	if err != nil {
+		return errors.Wrapf(err, "connecting to %s", addr)
+	}
+	defer db.Close()
+
+	if _, err := db.ExecContext(ctx, "START GROUP_REPLICATION"); err != nil {
+		return errors.Wrapf(err, "rejoining %s", addr)
+	}
+	return nil
+}
+
+func (c *sqlInstanceConfigurator) exec(ctx context.Context, stmt string) error {
+	db, err := c.dial()
+	if err != nil {
+		return errors.Wrap(err, "connecting to local instance")
+	}
+	defer db.Close()
+
+	if _, err := db.ExecContext(ctx, stmt); err != nil {
+		return errors.Wrapf(err, "executing %q", stmt)
+	}
+	return nil
+}
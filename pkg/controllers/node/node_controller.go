@@ -0,0 +1,191 @@
+// Package node runs the NodeController, the labeler's peer controller
+// responsible for making the local MySQL instance's configuration agree with
+// the InnoDB Cluster topology: setting super_read_only on demoted instances,
+// starting/stopping Group Replication, and rejoining instances the topology
+// reports as MISSING.
+package node
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/golang/glog"
+	"github.com/pkg/errors"
+
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	wait "k8s.io/apimachinery/pkg/util/wait"
+	workqueue "k8s.io/client-go/util/workqueue"
+
+	cluster "github.com/oracle/mysql-operator/pkg/cluster"
+	innodb "github.com/oracle/mysql-operator/pkg/cluster/innodb"
+	labeler "github.com/oracle/mysql-operator/pkg/controllers/cluster/labeler"
+)
+
+const controllerAgentName = "innodb-node-controller"
+
+// InstanceConfigurator drives the local MySQL instance's configuration and
+// Group Replication membership. It's implemented against the real server via
+// the mysql package; tests substitute a fake.
+type InstanceConfigurator interface {
+	// SetSuperReadOnly enables or disables super_read_only on the local
+	// instance.
+	SetSuperReadOnly(ctx context.Context, enabled bool) error
+	// StartGroupReplication starts Group Replication on the local instance.
+	StartGroupReplication(ctx context.Context) error
+	// StopGroupReplication stops Group Replication on the local instance.
+	StopGroupReplication(ctx context.Context) error
+	// RejoinInstance re-joins addr to the InnoDB Cluster, mirroring
+	// dba.rejoinInstance().
+	RejoinInstance(ctx context.Context, addr string) error
+}
+
+// NodeController reacts to the same innodb.ClusterStatus events as
+// labeler.ClusterLabelerController, consuming the typed labeler.Event values
+// the labeler pushes onto a shared workqueue, and reconciles the local
+// instance's configuration to match. Labeling and node configuration thereby
+// converge on the same workqueue instead of drifting independently.
+type NodeController struct {
+	localInstance *cluster.Instance
+	configurator  InstanceConfigurator
+
+	queue workqueue.RateLimitingInterface
+}
+
+// NewNodeController creates a NodeController that consumes labeler.Event
+// values off queue. queue is typically the same workqueue.Interface passed
+// to ClusterLabelerController.SetEventQueue, so that labeling and node
+// configuration converge together.
+func NewNodeController(
+	localInstance *cluster.Instance,
+	configurator InstanceConfigurator,
+	queue workqueue.RateLimitingInterface,
+) *NodeController {
+	return &NodeController{
+		localInstance: localInstance,
+		configurator:  configurator,
+		queue:         queue,
+	}
+}
+
+// Run runs the NodeController until ctx is cancelled.
+func (nc *NodeController) Run(ctx context.Context) {
+	defer utilruntime.HandleCrash()
+	defer nc.queue.ShutDown()
+
+	glog.Infof("Starting the NodeController")
+	go wait.Until(nc.runWorker, time.Second, ctx.Done())
+
+	glog.Info("Started NodeController worker")
+	defer glog.Info("Shutting down NodeController worker")
+	<-ctx.Done()
+}
+
+func (nc *NodeController) runWorker() {
+	for nc.processNextWorkItem() {
+	}
+}
+
+func (nc *NodeController) processNextWorkItem() bool {
+	obj, shutdown := nc.queue.Get()
+	if shutdown {
+		return false
+	}
+
+	err := func(obj interface{}) error {
+		defer nc.queue.Done(obj)
+
+		switch v := obj.(type) {
+		case labeler.Event:
+			if err := nc.syncHandler(v); err != nil {
+				return errors.Wrapf(err, "error syncing node config for %s/%s", v.Namespace, v.PodName)
+			}
+		case *innodb.ClusterStatus:
+			if err := nc.reconcileMissing(context.Background(), v); err != nil {
+				return errors.Wrapf(err, "error reconciling missing members for cluster %s", v.ClusterName)
+			}
+		default:
+			nc.queue.Forget(obj)
+			return fmt.Errorf("expected labeler.Event or *innodb.ClusterStatus got %T", obj)
+		}
+
+		nc.queue.Forget(obj)
+		return nil
+	}(obj)
+
+	if err != nil {
+		utilruntime.HandleError(err)
+		nc.queue.AddRateLimited(obj)
+	}
+
+	return true
+}
+
+// EnqueueClusterStatus queues status so its MISSING topology members (if
+// any) get rejoined by reconcileMissing. The agent binary calls this
+// alongside ClusterLabelerController.EnqueueClusterStatus, feeding both
+// controllers the same innodb.ClusterStatus updates, since the only
+// labeler.Event a MISSING member produces is its relabel to offline, which
+// syncHandler ignores on every NodeController but the member's own.
+func (nc *NodeController) EnqueueClusterStatus(status *innodb.ClusterStatus) {
+	nc.queue.Add(status)
+}
+
+// syncHandler reconciles the local instance's configuration with the role
+// transition described by event. Events for other Pods are ignored; each
+// NodeController only ever drives its own, co-located MySQL instance.
+func (nc *NodeController) syncHandler(event labeler.Event) error {
+	if event.PodName != nc.localInstance.Name() {
+		return nil
+	}
+
+	ctx := context.Background()
+
+	switch event.ToRole {
+	case labeler.MySQLClusterRoleRecovering:
+		if event.FromRole == labeler.MySQLClusterRoleOffline || event.FromRole == "" {
+			glog.Infof("Starting Group Replication on %s/%s to begin distributed recovery", event.Namespace, event.PodName)
+			if err := nc.configurator.StartGroupReplication(ctx); err != nil {
+				return errors.Wrap(err, "starting group replication")
+			}
+		}
+	case labeler.MySQLClusterRoleSecondary, labeler.MySQLClusterRoleReadOnlyReplica:
+		glog.Infof("Setting super_read_only on %s/%s after demotion to %s", event.Namespace, event.PodName, event.ToRole)
+		if err := nc.configurator.SetSuperReadOnly(ctx, true); err != nil {
+			return errors.Wrap(err, "setting super_read_only")
+		}
+	case labeler.MySQLClusterRolePrimary:
+		if err := nc.configurator.SetSuperReadOnly(ctx, false); err != nil {
+			return errors.Wrap(err, "clearing super_read_only")
+		}
+	case labeler.MySQLClusterRoleOffline:
+		glog.Infof("Stopping Group Replication on %s/%s as the topology reports it offline", event.Namespace, event.PodName)
+		if err := nc.configurator.StopGroupReplication(ctx); err != nil {
+			return errors.Wrap(err, "stopping group replication")
+		}
+	}
+
+	return nil
+}
+
+// reconcileMissing re-joins any Topology members reported as MISSING,
+// mirroring dba.rejoinInstance(). It's invoked from processNextWorkItem for
+// every *innodb.ClusterStatus queued via EnqueueClusterStatus, independently
+// of role-change events, since the labeler's only Event for a MISSING member
+// (its relabel to offline) is ignored by every NodeController but the
+// member's own. RejoinInstance dials addr directly rather than the local
+// instance, so every NodeController that observes the same status attempts
+// this concurrently; START GROUP_REPLICATION against a member that already
+// rejoined fails, and that failure is only logged.
+func (nc *NodeController) reconcileMissing(ctx context.Context, status *innodb.ClusterStatus) error {
+	for addr, inst := range status.DefaultReplicaSet.Topology {
+		if inst.Status != innodb.InstanceStatusMissing {
+			continue
+		}
+		glog.Infof("Rejoining missing instance %s", addr)
+		if err := nc.configurator.RejoinInstance(ctx, addr); err != nil {
+			glog.Warningf("Failed to rejoin %s, will retry on the next sync: %v", addr, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+// Package mysqlconn provides the one authenticated connector every
+// controller in this operator uses to reach a MySQL instance directly,
+// instead of each controller open-coding its own passwordless DSN.
+package mysqlconn
+
+import (
+	"database/sql"
+	"fmt"
+	"os"
+
+	"github.com/pkg/errors"
+
+	// Register the MySQL sql.DB driver.
+	_ "github.com/go-sql-driver/mysql"
+)
+
+// RootPasswordEnvVar names the environment variable the agent container is
+// expected to carry the InnoDB Cluster root password in, sourced from the
+// same Secret the operator provisions for the cluster and mounts into every
+// Pod running this agent.
+const RootPasswordEnvVar = "MYSQL_ROOT_PASSWORD"
+
+// Dial opens an authenticated connection to the MySQL instance reachable at
+// addr ("host:port"), using TLS when the server offers it.
+func Dial(addr string) (*sql.DB, error) {
+	password := os.Getenv(RootPasswordEnvVar)
+	if password == "" {
+		return nil, errors.Errorf("%s is not set", RootPasswordEnvVar)
+	}
+	return sql.Open("mysql", fmt.Sprintf("root:%s@tcp(%s)/?tls=preferred", password, addr))
+}